@@ -0,0 +1,96 @@
+package dune
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBodyTooLarge(t *testing.T) {
+	c := NewClient("k")
+	c.MaxResponseBytes = 8
+
+	resp := newTestResponse(http.StatusOK, "200 OK", nil, `{"result":"way more than eight bytes"}`)
+
+	var dest map[string]string
+	err := c.decodeBody(resp, &dest)
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("decodeBody err = %v, want *ResponseTooLargeError", err)
+	}
+	if tooLarge.Limit != 8 {
+		t.Errorf("ResponseTooLargeError.Limit = %d, want 8", tooLarge.Limit)
+	}
+}
+
+func TestDecodeBodyWithinLimit(t *testing.T) {
+	c := NewClient("k")
+	resp := newTestResponse(http.StatusOK, "200 OK", nil, `{"result":"ok"}`)
+
+	var dest struct {
+		Result string `json:"result"`
+	}
+	if err := c.decodeBody(resp, &dest); err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	if dest.Result != "ok" {
+		t.Errorf("Result = %q, want %q", dest.Result, "ok")
+	}
+}
+
+func TestDecodeIntoTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(strings.Repeat("a", 1024)))
+	}))
+	defer srv.Close()
+
+	c := NewClient("k")
+	c.MaxResponseBytes = 16
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = c.DecodeInto(context.Background(), req, &buf)
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("DecodeInto err = %v, want *ResponseTooLargeError", err)
+	}
+	if tooLarge.Limit != 16 {
+		t.Errorf("ResponseTooLargeError.Limit = %d, want 16", tooLarge.Limit)
+	}
+}
+
+func TestDecodeIntoWithinLimit(t *testing.T) {
+	const payload = "col1,col2\n1,2\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, payload)
+	}))
+	defer srv.Close()
+
+	c := NewClient("k")
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.DecodeInto(context.Background(), req, &buf); err != nil {
+		t.Fatalf("DecodeInto: %v", err)
+	}
+	if buf.String() != payload {
+		t.Errorf("streamed body = %q, want %q", buf.String(), payload)
+	}
+}
@@ -0,0 +1,26 @@
+package dune
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SlogRetryHook returns a RetryLogHook that logs each retry decision to
+// logger, so applications that don't want to write their own hook can opt
+// into structured retry logging with WithRetryPolicy(policy) where
+// policy.RetryLogHook is set to the result of this call.
+func SlogRetryHook(logger *slog.Logger) func(attempt int, resp *http.Response, err error, nextSleep time.Duration) {
+	return func(attempt int, resp *http.Response, err error, nextSleep time.Duration) {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		logger.Warn("dune: retrying request",
+			"attempt", attempt,
+			"status", status,
+			"error", err,
+			"sleep", nextSleep,
+		)
+	}
+}
@@ -0,0 +1,179 @@
+package dune
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type retryHookCall struct {
+	attempt   int
+	status    int
+	err       error
+	nextSleep time.Duration
+}
+
+func TestRetryLogHookFiresBeforeSleepWithDecision(t *testing.T) {
+	var mu sync.Mutex
+	var calls []retryHookCall
+
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient("k", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:          2,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		RetryLogHook: func(attempt int, resp *http.Response, err error, nextSleep time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			calls = append(calls, retryHookCall{attempt: attempt, status: status, err: err, nextSleep: nextSleep})
+		},
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 {
+		t.Fatalf("RetryLogHook called %d times, want 1", len(calls))
+	}
+	got := calls[0]
+	if got.attempt != 1 {
+		t.Errorf("attempt = %d, want 1", got.attempt)
+	}
+	if got.status != http.StatusServiceUnavailable {
+		t.Errorf("resp.StatusCode = %d, want 503", got.status)
+	}
+	if got.err == nil {
+		t.Error("err = nil, want the classified *APIError")
+	}
+	if _, ok := got.err.(*APIError); !ok {
+		t.Errorf("err = %T, want *APIError", got.err)
+	}
+	if got.nextSleep <= 0 {
+		t.Errorf("nextSleep = %s, want > 0", got.nextSleep)
+	}
+}
+
+func TestRetryLogHookFiresOnNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close() // nothing is listening on url anymore
+
+	var mu sync.Mutex
+	var calls []retryHookCall
+
+	c := NewClient("k", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		RetryLogHook: func(attempt int, resp *http.Response, err error, nextSleep time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, retryHookCall{attempt: attempt, err: err, nextSleep: nextSleep})
+		},
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if _, err := c.Do(context.Background(), req); err == nil {
+		t.Fatal("Do succeeded, want a connection error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 {
+		t.Fatalf("RetryLogHook called %d times, want 1", len(calls))
+	}
+	if calls[0].err == nil {
+		t.Error("err = nil, want the dial error")
+	}
+}
+
+func TestOnRateLimitFiresOnlyOnSuccessWithHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var seen []*RateLimit
+	c := NewClient("k", WithOnRateLimit(func(rl *RateLimit) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, rl)
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 1 {
+		t.Fatalf("OnRateLimit called %d times, want 1", len(seen))
+	}
+	if seen[0].Limit != 100 || seen[0].Remaining != 99 {
+		t.Errorf("RateLimit = %+v, want Limit=100 Remaining=99", seen[0])
+	}
+}
+
+func TestOnRateLimitDoesNotFireWithoutHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	called := false
+	c := NewClient("k", WithOnRateLimit(func(rl *RateLimit) { called = true }))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if called {
+		t.Error("OnRateLimit fired despite the response carrying no X-RateLimit-* headers")
+	}
+}
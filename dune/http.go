@@ -1,21 +1,49 @@
 package dune
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 var ErrorReqUnsuccessful = errors.New("request was not successful")
 
+// Sentinel errors for common response classes, so callers can branch on
+// error class with errors.Is instead of inspecting APIError.StatusCode.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// defaultMaxResponseBytes caps response bodies a Client will buffer before
+// decoding, so a hostile or misbehaving endpoint returning a multi-GB
+// payload can't OOM the process.
+const defaultMaxResponseBytes = 64 << 20 // 64 MiB
+
+// ResponseTooLargeError is returned when a response body exceeds the
+// client's MaxResponseBytes limit.
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds %d byte limit", e.Limit)
+}
+
 type RateLimit struct {
 	Limit     int
 	Remaining int
@@ -37,6 +65,110 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("http %d %s", e.StatusCode, e.StatusText)
 }
 
+// Unwrap lets errors.Is(err, ErrorReqUnsuccessful) match any APIError, and
+// additionally lets errors.Is(err, ErrUnauthorized/ErrNotFound/ErrRateLimited)
+// match based on StatusCode.
+func (e *APIError) Unwrap() []error {
+	errs := []error{ErrorReqUnsuccessful}
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		errs = append(errs, ErrUnauthorized)
+	case http.StatusNotFound:
+		errs = append(errs, ErrNotFound)
+	case http.StatusTooManyRequests:
+		errs = append(errs, ErrRateLimited)
+	}
+	return errs
+}
+
+// Logger is the logging interface Client uses to report retry activity.
+// Implementations must be safe for concurrent use. Use WithLogger to plug
+// in your own; the default discards everything.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Printf(string, ...interface{}) {}
+
+// Client is a Dune Analytics API client scoped to a single API key. The
+// zero value is not usable; construct one with NewClient.
+type Client struct {
+	APIKey      string
+	HTTPClient  *http.Client
+	RetryPolicy RetryPolicy
+	Logger      Logger
+
+	// MaxResponseBytes caps how much of a response body decodeBody and
+	// DecodeInto will read before giving up with a *ResponseTooLargeError.
+	MaxResponseBytes int64
+
+	// OnRateLimit, if set, is called with the parsed rate-limit state
+	// whenever a successful response carries X-RateLimit-* headers, so
+	// callers can drive dashboards or metrics without wrapping the client.
+	OnRateLimit func(*RateLimit)
+
+	limiter       *rate.Limiter
+	limiterMu     sync.Mutex
+	baselineLimit rate.Limit
+	baselineBurst int
+
+	throttleMu    sync.Mutex
+	throttleUntil time.Time
+	throttleCh    chan struct{}
+}
+
+// Option configures a Client constructed by NewClient.
+type Option func(*Client)
+
+// NewClient returns a Client authenticating with apiKey, using a default
+// *http.Client, RetryPolicy, and rate limit that can be overridden with
+// Option values.
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		APIKey:           apiKey,
+		HTTPClient:       http.DefaultClient,
+		RetryPolicy:      defaultRetryPolicy,
+		Logger:           noopLogger{},
+		MaxResponseBytes: defaultMaxResponseBytes,
+		baselineLimit:    rate.Limit(defaultRPS),
+		baselineBurst:    defaultBurst,
+	}
+	c.limiter = rate.NewLimiter(c.baselineLimit, c.baselineBurst)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithHTTPClient overrides the *http.Client used to send requests, so
+// callers can customize connection pooling, TLS, or proxy behavior.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.HTTPClient = hc }
+}
+
+// WithRetryPolicy overrides the RetryPolicy applied to failed requests.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) { c.RetryPolicy = p }
+}
+
+// WithLogger overrides the Logger used to report retry activity.
+func WithLogger(l Logger) Option {
+	return func(c *Client) { c.Logger = l }
+}
+
+// WithOnRateLimit sets a callback fired with the parsed rate-limit state
+// whenever a successful response carries X-RateLimit-* headers.
+func WithOnRateLimit(f func(*RateLimit)) Option {
+	return func(c *Client) { c.OnRateLimit = f }
+}
+
+// WithMaxResponseBytes overrides the maximum response body size the
+// client will buffer before decoding.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) { c.MaxResponseBytes = n }
+}
 
 func parseRateLimitHeaders(h http.Header) *RateLimit {
 	limStr := h.Get("X-RateLimit-Limit")
@@ -68,115 +200,188 @@ func parseRateLimitHeaders(h http.Header) *RateLimit {
 	return &RateLimit{Limit: limit, Remaining: remaining, Reset: reset}
 }
 
+// classifyResponse reads a non-200 response's body once, builds a fully
+// populated *APIError from it, and reports whether the response's status
+// code makes it eligible for retry under retryableCodes. Callers still
+// need to check the attempt count themselves.
+func classifyResponse(resp *http.Response, retryableCodes []int) (*APIError, bool) {
+	defer resp.Body.Close()
+	snippetBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	msg := string(snippetBytes)
+	var errorResp ErrorResponse
+	if err := json.Unmarshal(snippetBytes, &errorResp); err == nil && errorResp.Error != "" {
+		msg = errorResp.Error
+	}
+
+	retryAfter := time.Duration(0)
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+	}
+
+	apiErr := &APIError{
+		StatusCode:  resp.StatusCode,
+		StatusText:  resp.Status,
+		BodySnippet: msg,
+		RateLimit:   parseRateLimitHeaders(resp.Header),
+		RetryAfter:  retryAfter,
+	}
+
+	for _, code := range retryableCodes {
+		if resp.StatusCode == code {
+			return apiErr, true
+		}
+	}
+	return apiErr, false
+}
 
-func decodeBody(resp *http.Response, dest interface{}) error {
+// decodeBody JSON-decodes resp.Body into dest, capping the read at
+// c.MaxResponseBytes so a hostile or misbehaving endpoint can't OOM the
+// process.
+func (c *Client) decodeBody(resp *http.Response, dest interface{}) error {
 	defer resp.Body.Close()
-	err := json.NewDecoder(resp.Body).Decode(dest)
-	if err != nil {
+	limited := http.MaxBytesReader(nil, resp.Body, c.MaxResponseBytes)
+	if err := json.NewDecoder(limited).Decode(dest); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &ResponseTooLargeError{Limit: c.MaxResponseBytes}
+		}
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 	return nil
 }
 
-func httpRequest(apiKey string, req *http.Request) (*http.Response, error) {
-	req.Header.Add("X-DUNE-API-KEY", apiKey)
-	p := defaultRetryPolicy
+// DecodeInto sends req and streams the response body into w rather than
+// buffering it, for endpoints that return large CSV/Parquet result sets.
+// The stream is still capped at c.MaxResponseBytes.
+func (c *Client) DecodeInto(ctx context.Context, req *http.Request, w io.Writer) error {
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	limited := http.MaxBytesReader(nil, resp.Body, c.MaxResponseBytes)
+	if _, err := io.Copy(w, limited); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &ResponseTooLargeError{Limit: c.MaxResponseBytes}
+		}
+		return fmt.Errorf("failed to stream response: %w", err)
+	}
+	return nil
+}
+
+// sleep blocks for d or until ctx is done, whichever comes first.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bufferRequestBody ensures req has a GetBody func so every retry attempt
+// gets a fresh, unread copy of the body instead of resending an
+// already-drained reader. http.NewRequest already populates GetBody for
+// *bytes.Buffer/*bytes.Reader/*strings.Reader bodies; any other io.Reader
+// is buffered into memory once here, the same way Cloudflare's and
+// HashiCorp's retrying HTTP clients do it.
+func bufferRequestBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+	buf, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// Do sends req with the client's API key attached, retrying according to
+// c.RetryPolicy. It returns as soon as ctx is canceled, a non-retryable
+// response is received, or retries are exhausted.
+func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	req.Header.Set("X-DUNE-API-KEY", c.APIKey)
+	if err := bufferRequestBody(req); err != nil {
+		return nil, fmt.Errorf("failed to buffer request body: %w", err)
+	}
+
+	p := c.RetryPolicy
 	attempt := 1
 	for {
-		resp, err := http.DefaultClient.Do(req)
+		if err := c.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body for retry: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.HTTPClient.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			if attempt >= p.MaxAttempts {
 				return nil, fmt.Errorf("failed to send request: %w", err)
 			}
-			time.Sleep(p.NextBackoff(attempt))
+			c.Logger.Printf("dune: request error (attempt %d/%d): %v", attempt, p.MaxAttempts, err)
+			sleep := p.NextBackoff(attempt)
+			if p.RetryLogHook != nil {
+				p.RetryLogHook(attempt, nil, err, sleep)
+			}
+			if err := c.sleep(ctx, sleep); err != nil {
+				return nil, err
+			}
 			attempt++
 			continue
 		}
 
 		if resp.StatusCode == 200 {
+			rl := parseRateLimitHeaders(resp.Header)
+			c.tuneLimiter(rl)
+			if rl != nil && c.OnRateLimit != nil {
+				c.OnRateLimit(rl)
+			}
 			return resp, nil
 		}
 
-		defer resp.Body.Close()
-		snippetBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		var errorResp ErrorResponse
-		if err := json.Unmarshal(snippetBytes, &errorResp); err == nil && errorResp.Error != "" {
-			msg := errorResp.Error
-			rl := parseRateLimitHeaders(resp.Header)
-			retryAfter := time.Duration(0)
-			if ra := resp.Header.Get("Retry-After"); ra != "" {
-				if secs, err := strconv.Atoi(ra); err == nil {
-					retryAfter = time.Duration(secs) * time.Second
-				}
-			}
-			apiErr := &APIError{StatusCode: resp.StatusCode, StatusText: resp.Status, BodySnippet: msg, RateLimit: rl, RetryAfter: retryAfter}
-			retryable := false
-			for _, code := range p.RetryableStatusCodes {
-				if resp.StatusCode == code {
-					retryable = true
-					break
-				}
-			}
-			if retryable && attempt < p.MaxAttempts {
-				sleep := p.NextBackoff(attempt)
-				if apiErr.RetryAfter > 0 && apiErr.RetryAfter > sleep {
-					sleep = apiErr.RetryAfter
-				}
-				time.Sleep(sleep)
-				attempt++
-				continue
-			}
-			return nil, fmt.Errorf("%w: %v", ErrorReqUnsuccessful, apiErr)
-		} else {
-			msg := string(snippetBytes)
-			rl := parseRateLimitHeaders(resp.Header)
-			retryAfter := time.Duration(0)
-			if ra := resp.Header.Get("Retry-After"); ra != "" {
-				if secs, err := strconv.Atoi(ra); err == nil {
-					retryAfter = time.Duration(secs) * time.Second
-				}
-			}
-			apiErr := &APIError{StatusCode: resp.StatusCode, StatusText: resp.Status, BodySnippet: msg, RateLimit: rl, RetryAfter: retryAfter}
-			retryable := false
-			for _, code := range p.RetryableStatusCodes {
-				if resp.StatusCode == code {
-					retryable = true
-					break
-				}
-			}
-			if retryable && attempt < p.MaxAttempts {
-				sleep := p.NextBackoff(attempt)
-				if apiErr.RetryAfter > 0 && apiErr.RetryAfter > sleep {
-					sleep = apiErr.RetryAfter
-				}
-				time.Sleep(sleep)
-				attempt++
-				continue
-			}
-			return nil, fmt.Errorf("%w: %v", ErrorReqUnsuccessful, apiErr)
+		apiErr, retryable := classifyResponse(resp, p.RetryableStatusCodes)
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			c.triggerThrottle(throttleResetTime(apiErr))
 		}
-		rl := parseRateLimitHeaders(resp.Header)
-		retryAfter := time.Duration(0)
-		if ra := resp.Header.Get("Retry-After"); ra != "" {
-			if secs, err := strconv.Atoi(ra); err == nil {
-				retryAfter = time.Duration(secs) * time.Second
+
+		if retryable && attempt < p.MaxAttempts {
+			sleep := p.NextBackoff(attempt)
+			if apiErr.RetryAfter > 0 && apiErr.RetryAfter > sleep {
+				sleep = apiErr.RetryAfter
 			}
-		}
-		apiErr := &APIError{
-			StatusCode:  resp.StatusCode,
-			StatusText: resp.Status,
-			BodySnippet: msg,
-			RateLimit:  rl,
-			RetryAfter: retryAfter,
-		}
-		retryable := false
-		for _, code := range p.RetryableStatusCodes {
-			if resp.StatusCode == code {
-				retryable = true
-				break
+			c.Logger.Printf("dune: retrying after %s (attempt %d/%d): %v", sleep, attempt, p.MaxAttempts, apiErr)
+			if p.RetryLogHook != nil {
+				p.RetryLogHook(attempt, resp, apiErr, sleep)
 			}
+			if err := c.sleep(ctx, sleep); err != nil {
+				return nil, err
+			}
+			attempt++
+			continue
 		}
-		// unreachable due to early returns above; kept for clarity
-		return nil, fmt.Errorf("%w: unexpected error state", ErrorReqUnsuccessful)
+		return nil, apiErr
 	}
 }
@@ -0,0 +1,101 @@
+package dune
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestResponse(status int, statusText string, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     statusText,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestClassifyResponseJSONBody(t *testing.T) {
+	resp := newTestResponse(http.StatusUnauthorized, "401 Unauthorized", nil, `{"error":"invalid api key"}`)
+
+	apiErr, retryable := classifyResponse(resp, defaultRetryPolicy.RetryableStatusCodes)
+	if retryable {
+		t.Errorf("retryable = true, want false for 401")
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("StatusCode = %d, want 401", apiErr.StatusCode)
+	}
+	if apiErr.BodySnippet != "invalid api key" {
+		t.Errorf("BodySnippet = %q, want the unwrapped error field, not the raw JSON", apiErr.BodySnippet)
+	}
+
+	if !errors.Is(apiErr, ErrorReqUnsuccessful) {
+		t.Error("errors.Is(apiErr, ErrorReqUnsuccessful) = false, want true")
+	}
+	if !errors.Is(apiErr, ErrUnauthorized) {
+		t.Error("errors.Is(apiErr, ErrUnauthorized) = false, want true")
+	}
+	if errors.Is(apiErr, ErrNotFound) {
+		t.Error("errors.Is(apiErr, ErrNotFound) = true, want false")
+	}
+
+	var target *APIError
+	if !errors.As(apiErr, &target) {
+		t.Fatal("errors.As(apiErr, &target) = false, want true")
+	}
+	if target != apiErr {
+		t.Error("errors.As populated target with a different *APIError")
+	}
+}
+
+func TestClassifyResponseRawBody(t *testing.T) {
+	resp := newTestResponse(http.StatusNotFound, "404 Not Found", nil, "not found")
+
+	apiErr, retryable := classifyResponse(resp, defaultRetryPolicy.RetryableStatusCodes)
+	if retryable {
+		t.Errorf("retryable = true, want false for 404")
+	}
+	if apiErr.BodySnippet != "not found" {
+		t.Errorf("BodySnippet = %q, want raw body passed through unchanged", apiErr.BodySnippet)
+	}
+	if !errors.Is(apiErr, ErrNotFound) {
+		t.Error("errors.Is(apiErr, ErrNotFound) = false, want true")
+	}
+}
+
+func TestClassifyResponseRateLimitedIsRetryable(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "10")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", "1700000000")
+	header.Set("Retry-After", "5")
+	resp := newTestResponse(http.StatusTooManyRequests, "429 Too Many Requests", header, `{"error":"rate limited"}`)
+
+	apiErr, retryable := classifyResponse(resp, defaultRetryPolicy.RetryableStatusCodes)
+	if !retryable {
+		t.Error("retryable = false, want true for 429 with a configured retryable code")
+	}
+	if !errors.Is(apiErr, ErrRateLimited) {
+		t.Error("errors.Is(apiErr, ErrRateLimited) = false, want true")
+	}
+	if apiErr.RateLimit == nil || apiErr.RateLimit.Limit != 10 {
+		t.Errorf("RateLimit = %+v, want parsed Limit 10", apiErr.RateLimit)
+	}
+	if apiErr.RetryAfter != 5e9 {
+		t.Errorf("RetryAfter = %s, want 5s", apiErr.RetryAfter)
+	}
+}
+
+func TestClassifyResponseNotInRetryableList(t *testing.T) {
+	resp := newTestResponse(http.StatusInternalServerError, "500 Internal Server Error", nil, `{"error":"boom"}`)
+
+	_, retryable := classifyResponse(resp, []int{429})
+	if retryable {
+		t.Error("retryable = true, want false when 500 isn't in the configured RetryableStatusCodes")
+	}
+}
@@ -0,0 +1,126 @@
+package dune
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultRPS and defaultBurst seed a Client's limiter until real
+// X-RateLimit headers are observed. They're deliberately generous so an
+// unconfigured client doesn't self-throttle against an API that turns out
+// to have a much higher limit.
+const (
+	defaultRPS   = 10
+	defaultBurst = 10
+)
+
+// WithRateLimit sets the initial requests-per-second and burst a Client's
+// limiter uses before it has seen any X-RateLimit-* headers. Once headers
+// arrive, the limiter retunes itself to match the server's reported limit
+// and converges back to this baseline as headroom reappears.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.baselineLimit = rate.Limit(rps)
+		c.baselineBurst = burst
+		c.limiter = rate.NewLimiter(c.baselineLimit, burst)
+	}
+}
+
+// Wait blocks until the client has capacity to send another request,
+// honoring both the configured rate limit and any server-imposed
+// throttling observed from a previous 429. Advanced callers can use this
+// to reserve capacity before enqueuing work of their own.
+func (c *Client) Wait(ctx context.Context) error {
+	if err := c.waitForThrottle(ctx); err != nil {
+		return err
+	}
+	return c.currentLimiter().Wait(ctx)
+}
+
+// currentLimiter returns the client's limiter under limiterMu, since
+// tuneLimiter may replace it concurrently with rate.NewLimiter.
+func (c *Client) currentLimiter() *rate.Limiter {
+	c.limiterMu.Lock()
+	defer c.limiterMu.Unlock()
+	return c.limiter
+}
+
+// tuneLimiter retunes the client's limiter from a successful response's
+// rate-limit headers. When the server reports no remaining requests, the
+// limiter is narrowed so its rate matches exactly what's needed to spend
+// Limit requests by Reset; otherwise it converges back to the baseline.
+func (c *Client) tuneLimiter(rl *RateLimit) {
+	if rl == nil || rl.Limit <= 0 {
+		return
+	}
+
+	c.limiterMu.Lock()
+	defer c.limiterMu.Unlock()
+
+	if rl.Remaining == 0 {
+		if d := time.Until(time.Unix(rl.Reset, 0)); d > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(float64(rl.Limit)/d.Seconds()), rl.Limit)
+			return
+		}
+	}
+
+	c.limiter.SetBurst(c.baselineBurst)
+	c.limiter.SetLimit(c.baselineLimit)
+}
+
+// triggerThrottle makes every call to Wait that starts after it and before
+// until block together until until, so a burst of concurrent callers backs
+// off cooperatively instead of each sleeping and retrying independently.
+// Calls already inside Wait keep waiting on whatever throttle (if any) they
+// saw when they entered; a new, later throttle does not extend them.
+func (c *Client) triggerThrottle(until time.Time) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	if c.throttleCh != nil && !until.After(c.throttleUntil) {
+		return
+	}
+
+	ch := make(chan struct{})
+	c.throttleUntil = until
+	c.throttleCh = ch
+
+	go func() {
+		if d := time.Until(until); d > 0 {
+			time.Sleep(d)
+		}
+		c.throttleMu.Lock()
+		if c.throttleCh == ch {
+			c.throttleCh = nil
+		}
+		c.throttleMu.Unlock()
+		close(ch)
+	}()
+}
+
+// throttleResetTime picks the moment a 429 response's throttle should
+// lift, preferring the parsed X-RateLimit-Reset timestamp and falling
+// back to Retry-After when the server didn't send rate-limit headers.
+func throttleResetTime(apiErr *APIError) time.Time {
+	if apiErr.RateLimit != nil && apiErr.RateLimit.Reset > 0 {
+		return time.Unix(apiErr.RateLimit.Reset, 0)
+	}
+	return time.Now().Add(apiErr.RetryAfter)
+}
+
+func (c *Client) waitForThrottle(ctx context.Context) error {
+	c.throttleMu.Lock()
+	ch := c.throttleCh
+	c.throttleMu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,36 @@
+package dune
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestTuneLimiterConvergesToBaselineBurst(t *testing.T) {
+	c := NewClient("k", WithRateLimit(5, 5))
+	c.tuneLimiter(&RateLimit{Limit: 1000, Remaining: 500})
+
+	if got := c.limiter.Burst(); got != 5 {
+		t.Errorf("limiter.Burst() = %d, want baseline burst 5", got)
+	}
+	if got := c.limiter.Limit(); got != 5 {
+		t.Errorf("limiter.Limit() = %v, want baseline limit 5", got)
+	}
+}
+
+func TestWaitTuneLimiterConcurrent(t *testing.T) {
+	c := NewClient("k")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.tuneLimiter(&RateLimit{Limit: 10, Remaining: 0, Reset: 9999999999})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = c.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+}
@@ -1,13 +1,44 @@
 package dune
 
-import "time"
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// JitterMode selects how NextBackoff randomizes the delay between retries.
+type JitterMode int
+
+const (
+	// JitterFixed adds a constant Jitter duration on top of the
+	// exponential base delay. This is the package's original behavior.
+	JitterFixed JitterMode = iota
+	// JitterFull draws the delay uniformly from [0, base), per AWS's
+	// "full jitter" recipe. Recommended for concurrent callers, since it
+	// avoids synchronizing retries into a thundering herd.
+	JitterFull
+	// JitterEqual keeps half the base delay and adds a uniform random
+	// amount drawn from the other half.
+	JitterEqual
+)
+
+// randInt64N is a seam over rand.Int64N so tests can inject a
+// deterministic source.
+var randInt64N = rand.Int64N
 
 type RetryPolicy struct {
 	MaxAttempts          int
 	InitialBackoff       time.Duration
 	MaxBackoff           time.Duration
 	Jitter               time.Duration
+	JitterMode           JitterMode
 	RetryableStatusCodes []int
+
+	// RetryLogHook, if set, is called after every retry decision and
+	// before the resulting sleep. resp is nil when the attempt failed
+	// below the HTTP layer (e.g. a dial error), in which case err holds
+	// that error; otherwise err holds the *APIError classified from resp.
+	RetryLogHook func(attempt int, resp *http.Response, err error, nextSleep time.Duration)
 }
 
 var defaultRetryPolicy = RetryPolicy{
@@ -15,20 +46,41 @@ var defaultRetryPolicy = RetryPolicy{
 	InitialBackoff:       2 * time.Second,
 	MaxBackoff:           60 * time.Second,
 	Jitter:               250 * time.Millisecond,
+	JitterMode:           JitterFixed,
 	RetryableStatusCodes: []int{429, 500, 502, 503, 504},
 }
 
+// NextBackoff returns the delay to wait after the given attempt (1-indexed)
+// has failed, before the next attempt is sent. The base delay is InitialBackoff * 2^(attempt-1), capped at
+// MaxBackoff, computed via bit shifts rather than a loop and guarded
+// against overflowing time.Duration. JitterMode then determines how
+// randomness is layered on top of that base.
 func (p RetryPolicy) NextBackoff(attempt int) time.Duration {
-	b := p.InitialBackoff
-	for i := 1; i < attempt; i++ {
-		b *= 2
-		if b > p.MaxBackoff {
-			b = p.MaxBackoff
-			break
+	base := p.InitialBackoff
+	if shift := attempt - 1; shift > 0 {
+		if shift >= 62 || p.InitialBackoff > p.MaxBackoff>>uint(shift) {
+			base = p.MaxBackoff
+		} else {
+			base = p.InitialBackoff << uint(shift)
 		}
 	}
-	if p.Jitter > 0 {
-		b += p.Jitter
+	if base > p.MaxBackoff {
+		base = p.MaxBackoff
+	}
+	if base <= 0 {
+		return 0
+	}
+
+	switch p.JitterMode {
+	case JitterFull:
+		return time.Duration(randInt64N(int64(base)))
+	case JitterEqual:
+		half := base / 2
+		return half + time.Duration(randInt64N(int64(half)+1))
+	default:
+		if p.Jitter > 0 {
+			base += p.Jitter
+		}
+		return base
 	}
-	return b
 }
@@ -0,0 +1,82 @@
+package dune
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBackoffBase(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     10 * time.Second,
+		JitterMode:     JitterFixed,
+	}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // capped at MaxBackoff
+		{20, 10 * time.Second},
+	}
+	for _, c := range cases {
+		if got := p.NextBackoff(c.attempt); got != c.want {
+			t.Errorf("NextBackoff(%d) = %s, want %s", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestNextBackoffJitterFull(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     10 * time.Second,
+		JitterMode:     JitterFull,
+	}
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		base := p.InitialBackoff << uint(attempt-1)
+		if base > p.MaxBackoff || base <= 0 {
+			base = p.MaxBackoff
+		}
+		for i := 0; i < 50; i++ {
+			got := p.NextBackoff(attempt)
+			if got < 0 || got >= base {
+				t.Fatalf("attempt %d: NextBackoff() = %s, want in [0, %s)", attempt, got, base)
+			}
+		}
+	}
+}
+
+func TestNextBackoffJitterEqual(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     10 * time.Second,
+		JitterMode:     JitterEqual,
+	}
+
+	base := 8 * time.Second // attempt 3: 2s * 2^2
+	half := base / 2
+	for i := 0; i < 50; i++ {
+		got := p.NextBackoff(3)
+		if got < half || got > base {
+			t.Fatalf("NextBackoff(3) = %s, want in [%s, %s]", got, half, base)
+		}
+	}
+}
+
+func TestNextBackoffJitterFixed(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         250 * time.Millisecond,
+		JitterMode:     JitterFixed,
+	}
+	want := 2*time.Second + 250*time.Millisecond
+	if got := p.NextBackoff(2); got != want {
+		t.Errorf("NextBackoff(2) = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package dune
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// onceReadCloser wraps an io.Reader without being one of the magic stdlib
+// types (*bytes.Buffer/*bytes.Reader/*strings.Reader) that http.NewRequest
+// auto-populates GetBody for, so it reproduces the body-not-rewound bug.
+type onceReadCloser struct {
+	io.Reader
+}
+
+func (onceReadCloser) Close() error { return nil }
+
+func TestDoRewindsBodyOnRetry(t *testing.T) {
+	const body = `{"query_id":17}`
+
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		got, _ := io.ReadAll(r.Body)
+		if string(got) != body {
+			t.Errorf("attempt %d: body = %q, want %q", n, got, body)
+		}
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, onceReadCloser{bytes.NewReader([]byte(body))})
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.ContentLength = int64(len(body))
+
+	c := NewClient("k", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:          2,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+
+	resp, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Fatalf("server saw %d attempts, want 2", got)
+	}
+}